@@ -0,0 +1,144 @@
+// Copyright 2019 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseNextMsgReq(t *testing.T) {
+	cases := []struct {
+		name       string
+		msg        []byte
+		wantBatch  int
+		wantNoWait bool
+	}{
+		{"nil payload defaults to batch 1", nil, 1, false},
+		{"whitespace-only payload does not panic", []byte("   "), 1, false},
+		{"bare integer is the batch size", []byte("5"), 5, false},
+		{"json object with no_wait", []byte(`{"batch":3,"no_wait":true}`), 3, true},
+		{"json object with batch 0 falls back to 1", []byte(`{"batch":0}`), 1, false},
+		{"json object wrapped in whitespace", []byte("  {\"batch\":2}  "), 2, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			batch, _, noWait := parseNextMsgReq(c.msg)
+			if batch != c.wantBatch {
+				t.Fatalf("batch = %d, want %d", batch, c.wantBatch)
+			}
+			if noWait != c.wantNoWait {
+				t.Fatalf("noWait = %v, want %v", noWait, c.wantNoWait)
+			}
+		})
+	}
+}
+
+func TestBatchSizeFromMsg(t *testing.T) {
+	if bs := batchSizeFromMsg(nil); bs != 1 {
+		t.Fatalf("batchSizeFromMsg(nil) = %d, want 1", bs)
+	}
+	if bs := batchSizeFromMsg([]byte("7")); bs != 7 {
+		t.Fatalf("batchSizeFromMsg(\"7\") = %d, want 7", bs)
+	}
+	if bs := batchSizeFromMsg([]byte("not-a-number")); bs != 1 {
+		t.Fatalf("batchSizeFromMsg(garbage) = %d, want 1", bs)
+	}
+}
+
+func TestIsDurableObservable(t *testing.T) {
+	if isDurableObservable(nil) {
+		t.Fatal("nil config should not be durable")
+	}
+	if isDurableObservable(&ObservableConfig{}) {
+		t.Fatal("empty Durable should not be durable")
+	}
+	if !isDurableObservable(&ObservableConfig{Durable: "my-durable"}) {
+		t.Fatal("non-empty Durable should be durable")
+	}
+}
+
+func TestAckReplyForAndSeqFromReply(t *testing.T) {
+	o := &Observable{config: ObservableConfig{}}
+	o.ackReply = "$JS.ACK.test.obs.%d"
+
+	reply := o.ackReplyFor(42)
+	if got := o.SeqFromReply(reply); got != 42 {
+		t.Fatalf("SeqFromReply(%q) = %d, want 42", reply, got)
+	}
+}
+
+func TestAckReplyForAndSeqFromReplyOrdered(t *testing.T) {
+	o := &Observable{config: ObservableConfig{Ordered: true}}
+	o.ackReply = "$JS.ACK.test.obs.%d.%d"
+	o.seq = 100
+
+	reply := o.ackReplyFor(7)
+	if got := o.SeqFromReply(reply); got != 7 {
+		t.Fatalf("SeqFromReply(%q) = %d, want 7", reply, got)
+	}
+}
+
+func TestExtractCloudEventHeaders(t *testing.T) {
+	body := []byte(`{"id":"123","source":"/test","specversion":"1.0","type":"com.example.test","time":"2020-01-01T00:00:00Z","data":{"hello":"world"}}`)
+
+	hdr, data, ok := extractCloudEventHeaders("application/cloudevents+json", body)
+	if !ok {
+		t.Fatal("expected ok=true for a valid structured CloudEvent")
+	}
+	if want := "ce-id: 123\r\n"; !strings.Contains(hdr, want) {
+		t.Fatalf("header %q missing %q", hdr, want)
+	}
+	if want := "ce-type: com.example.test\r\n"; !strings.Contains(hdr, want) {
+		t.Fatalf("header %q missing %q", hdr, want)
+	}
+	if string(data) != `{"hello":"world"}` {
+		t.Fatalf("data = %s, want the event's data section", data)
+	}
+
+	if _, _, ok := extractCloudEventHeaders("application/json", body); ok {
+		t.Fatal("expected ok=false for a non-CloudEvents content type")
+	}
+	if _, _, ok := extractCloudEventHeaders("application/cloudevents+json", []byte("not json")); ok {
+		t.Fatal("expected ok=false for an unparseable body")
+	}
+}
+
+func TestFileObservableStore(t *testing.T) {
+	s := &fileObservableStore{file: filepath.Join(t.TempDir(), "obs.json")}
+
+	if _, err := s.State(); err == nil {
+		t.Fatal("expected an error reading state before any Update")
+	}
+
+	want := &ObservableState{Delivered: SequencePair{ConsumerSeq: 5, StreamSeq: 9}}
+	if err := s.Update(want); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got, err := s.State()
+	if err != nil {
+		t.Fatalf("State failed: %v", err)
+	}
+	if got.Delivered != want.Delivered {
+		t.Fatalf("State() = %+v, want %+v", got.Delivered, want.Delivered)
+	}
+
+	if err := s.Delete(); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := s.State(); err == nil {
+		t.Fatal("expected an error reading state after Delete")
+	}
+}